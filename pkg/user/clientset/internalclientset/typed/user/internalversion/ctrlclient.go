@@ -0,0 +1,55 @@
+package internalversion
+
+import (
+	api "github.com/openshift/origin/pkg/user/api"
+	v1 "github.com/openshift/origin/pkg/user/api/v1"
+	restclient "k8s.io/kubernetes/pkg/client/restclient"
+	runtime "k8s.io/kubernetes/pkg/runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SchemeBuilder collects the user.openshift.io internal and versioned kinds
+// so they can be registered together with AddToScheme.
+var SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+// AddToScheme registers the User, Group, Identity, and UserIdentityMapping
+// internal and versioned GroupVersionKinds with the given scheme.
+var AddToScheme = SchemeBuilder.AddToScheme
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(api.SchemeGroupVersion,
+		&api.User{},
+		&api.UserList{},
+		&api.Group{},
+		&api.GroupList{},
+		&api.Identity{},
+		&api.IdentityList{},
+		&api.UserIdentityMapping{},
+	)
+	scheme.AddKnownTypes(v1.SchemeGroupVersion,
+		&v1.User{},
+		&v1.UserList{},
+		&v1.Group{},
+		&v1.GroupList{},
+		&v1.Identity{},
+		&v1.IdentityList{},
+		&v1.UserIdentityMapping{},
+	)
+	return nil
+}
+
+// NewCtrlClient returns a controller-runtime client.Client that is
+// pre-registered with the user.openshift.io scheme (User, Group, Identity,
+// UserIdentityMapping), so operators built on controller-runtime can use
+// typed List/Get on these resources without falling back to raw REST calls.
+func NewCtrlClient(c *restclient.Config, opts ctrlclient.Options) (ctrlclient.Client, error) {
+	if opts.Scheme == nil {
+		opts.Scheme = runtime.NewScheme()
+	}
+	if err := AddToScheme(opts.Scheme); err != nil {
+		return nil, err
+	}
+
+	restConfig := *c
+	return ctrlclient.New(&restConfig, opts)
+}
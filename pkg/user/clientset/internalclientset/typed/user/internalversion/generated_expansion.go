@@ -0,0 +1,9 @@
+package internalversion
+
+type UserExpansion interface{}
+
+type GroupExpansion interface{}
+
+type IdentityExpansion interface{}
+
+type UserIdentityMappingExpansion interface{}
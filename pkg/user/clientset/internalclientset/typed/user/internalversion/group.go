@@ -0,0 +1,115 @@
+package internalversion
+
+import (
+	api "github.com/openshift/origin/pkg/user/api"
+	kapi "k8s.io/kubernetes/pkg/api"
+	restclient "k8s.io/kubernetes/pkg/client/restclient"
+	watch "k8s.io/kubernetes/pkg/watch"
+)
+
+// GroupsGetter has a method to return a GroupResourceInterface.
+// A group's client should implement this interface.
+//
+// Group is cluster-scoped, so unlike namespaced resources there is no
+// namespace to scope the returned interface to.
+type GroupsGetter interface {
+	Groups() GroupResourceInterface
+}
+
+// GroupResourceInterface has methods to work with Group resources.
+type GroupResourceInterface interface {
+	Create(*api.Group) (*api.Group, error)
+	Update(*api.Group) (*api.Group, error)
+	Delete(name string, options *kapi.DeleteOptions) error
+	DeleteCollection(options *kapi.DeleteOptions, listOptions kapi.ListOptions) error
+	Get(name string) (*api.Group, error)
+	List(opts kapi.ListOptions) (*api.GroupList, error)
+	Watch(opts kapi.ListOptions) (watch.Interface, error)
+	GroupExpansion
+}
+
+// groups implements GroupResourceInterface
+type groups struct {
+	client restclient.Interface
+}
+
+// newGroups returns a Groups
+func newGroups(c *UserClient) *groups {
+	return &groups{
+		client: c.RESTClient(),
+	}
+}
+
+// Create takes the representation of a group and creates it.  Returns the server's representation of the group, and an error, if there is any.
+func (c *groups) Create(group *api.Group) (result *api.Group, err error) {
+	result = &api.Group{}
+	err = c.client.Post().
+		Resource("groups").
+		Body(group).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a group and updates it. Returns the server's representation of the group, and an error, if there is any.
+func (c *groups) Update(group *api.Group) (result *api.Group, err error) {
+	result = &api.Group{}
+	err = c.client.Put().
+		Resource("groups").
+		Name(group.Name).
+		Body(group).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the group and deletes it. Returns an error if one occurs.
+func (c *groups) Delete(name string, options *kapi.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("groups").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *groups) DeleteCollection(options *kapi.DeleteOptions, listOptions kapi.ListOptions) error {
+	return c.client.Delete().
+		Resource("groups").
+		VersionedParams(&listOptions, kapi.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Get takes name of the group, and returns the corresponding group object, and an error if there is any.
+func (c *groups) Get(name string) (result *api.Group, err error) {
+	result = &api.Group{}
+	err = c.client.Get().
+		Resource("groups").
+		Name(name).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of Groups that match those selectors.
+func (c *groups) List(opts kapi.ListOptions) (result *api.GroupList, err error) {
+	result = &api.GroupList{}
+	err = c.client.Get().
+		Resource("groups").
+		VersionedParams(&opts, kapi.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested groups.
+func (c *groups) Watch(opts kapi.ListOptions) (watch.Interface, error) {
+	return c.client.Get().
+		Prefix("watch").
+		Resource("groups").
+		VersionedParams(&opts, kapi.ParameterCodec).
+		Watch()
+}
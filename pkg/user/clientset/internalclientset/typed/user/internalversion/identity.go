@@ -0,0 +1,115 @@
+package internalversion
+
+import (
+	api "github.com/openshift/origin/pkg/user/api"
+	kapi "k8s.io/kubernetes/pkg/api"
+	restclient "k8s.io/kubernetes/pkg/client/restclient"
+	watch "k8s.io/kubernetes/pkg/watch"
+)
+
+// IdentitiesGetter has a method to return an IdentityResourceInterface.
+// An identity's client should implement this interface.
+//
+// Identity is cluster-scoped, so unlike namespaced resources there is no
+// namespace to scope the returned interface to.
+type IdentitiesGetter interface {
+	Identities() IdentityResourceInterface
+}
+
+// IdentityResourceInterface has methods to work with Identity resources.
+type IdentityResourceInterface interface {
+	Create(*api.Identity) (*api.Identity, error)
+	Update(*api.Identity) (*api.Identity, error)
+	Delete(name string, options *kapi.DeleteOptions) error
+	DeleteCollection(options *kapi.DeleteOptions, listOptions kapi.ListOptions) error
+	Get(name string) (*api.Identity, error)
+	List(opts kapi.ListOptions) (*api.IdentityList, error)
+	Watch(opts kapi.ListOptions) (watch.Interface, error)
+	IdentityExpansion
+}
+
+// identities implements IdentityResourceInterface
+type identities struct {
+	client restclient.Interface
+}
+
+// newIdentities returns an Identities
+func newIdentities(c *UserClient) *identities {
+	return &identities{
+		client: c.RESTClient(),
+	}
+}
+
+// Create takes the representation of an identity and creates it.  Returns the server's representation of the identity, and an error, if there is any.
+func (c *identities) Create(identity *api.Identity) (result *api.Identity, err error) {
+	result = &api.Identity{}
+	err = c.client.Post().
+		Resource("identities").
+		Body(identity).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of an identity and updates it. Returns the server's representation of the identity, and an error, if there is any.
+func (c *identities) Update(identity *api.Identity) (result *api.Identity, err error) {
+	result = &api.Identity{}
+	err = c.client.Put().
+		Resource("identities").
+		Name(identity.Name).
+		Body(identity).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the identity and deletes it. Returns an error if one occurs.
+func (c *identities) Delete(name string, options *kapi.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("identities").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *identities) DeleteCollection(options *kapi.DeleteOptions, listOptions kapi.ListOptions) error {
+	return c.client.Delete().
+		Resource("identities").
+		VersionedParams(&listOptions, kapi.ParameterCodec).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Get takes name of the identity, and returns the corresponding identity object, and an error if there is any.
+func (c *identities) Get(name string) (result *api.Identity, err error) {
+	result = &api.Identity{}
+	err = c.client.Get().
+		Resource("identities").
+		Name(name).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of Identities that match those selectors.
+func (c *identities) List(opts kapi.ListOptions) (result *api.IdentityList, err error) {
+	result = &api.IdentityList{}
+	err = c.client.Get().
+		Resource("identities").
+		VersionedParams(&opts, kapi.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested identities.
+func (c *identities) Watch(opts kapi.ListOptions) (watch.Interface, error) {
+	return c.client.Get().
+		Prefix("watch").
+		Resource("identities").
+		VersionedParams(&opts, kapi.ParameterCodec).
+		Watch()
+}
@@ -1,25 +1,44 @@
 package internalversion
 
 import (
+	"fmt"
+
 	api "k8s.io/kubernetes/pkg/api"
 	registered "k8s.io/kubernetes/pkg/apimachinery/registered"
 	restclient "k8s.io/kubernetes/pkg/client/restclient"
+	discovery "k8s.io/kubernetes/pkg/client/typed/discovery"
 )
 
 type UserInterface interface {
 	RESTClient() restclient.Interface
 	UsersGetter
+	GroupsGetter
+	IdentitiesGetter
+	UserIdentityMappingsGetter
 }
 
 // UserClient is used to interact with features provided by the k8s.io/kubernetes/pkg/apimachinery/registered.Group group.
 type UserClient struct {
-	restClient restclient.Interface
+	restClient      restclient.Interface
+	DiscoveryClient *discovery.DiscoveryClient
 }
 
 func (c *UserClient) Users(namespace string) UserResourceInterface {
 	return newUsers(c, namespace)
 }
 
+func (c *UserClient) Groups() GroupResourceInterface {
+	return newGroups(c)
+}
+
+func (c *UserClient) Identities() IdentityResourceInterface {
+	return newIdentities(c)
+}
+
+func (c *UserClient) UserIdentityMappings() UserIdentityMappingResourceInterface {
+	return newUserIdentityMappings(c)
+}
+
 // NewForConfig creates a new UserClient for the given config.
 func NewForConfig(c *restclient.Config) (*UserClient, error) {
 	config := *c
@@ -30,7 +49,14 @@ func NewForConfig(c *restclient.Config) (*UserClient, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &UserClient{client}, nil
+
+	discoveryConfig := *c
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&discoveryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserClient{restClient: client, DiscoveryClient: discoveryClient}, nil
 }
 
 // NewForConfigOrDie creates a new UserClient for the given config and
@@ -45,7 +71,52 @@ func NewForConfigOrDie(c *restclient.Config) *UserClient {
 
 // New creates a new UserClient for the given RESTClient.
 func New(c restclient.Interface) *UserClient {
-	return &UserClient{c}
+	return &UserClient{restClient: c}
+}
+
+// Discovery retrieves the DiscoveryClient
+func (c *UserClient) Discovery() discovery.DiscoveryInterface {
+	if c == nil {
+		return nil
+	}
+	return c.DiscoveryClient
+}
+
+// TokenRetriever fetches a bearer token that authenticates as the given
+// service account, for use by controllers that must act on that
+// service account's behalf.
+type TokenRetriever interface {
+	GetToken(namespace, name string) (string, error)
+}
+
+// NewForServiceAccount creates a new UserClient that authenticates as the
+// named service account, following the same Clients() pattern used by other
+// controllers that act on behalf of a service account: the incoming config
+// is copied, any user-supplied credentials are stripped, the UserAgent is
+// annotated with the service account identity, and the service account's
+// token (obtained via tokenRetriever) becomes the bearer token for the
+// returned client.
+func NewForServiceAccount(config *restclient.Config, tokenRetriever TokenRetriever, namespace, name string) (*UserClient, error) {
+	saConfig := *config
+	saConfig.Username = ""
+	saConfig.Password = ""
+	saConfig.CertFile = ""
+	saConfig.CertData = nil
+	saConfig.KeyFile = ""
+	saConfig.KeyData = nil
+	saConfig.BearerToken = ""
+	if saConfig.UserAgent == "" {
+		saConfig.UserAgent = restclient.DefaultKubernetesUserAgent()
+	}
+	saConfig.UserAgent = fmt.Sprintf("%s system:serviceaccount:%s:%s", saConfig.UserAgent, namespace, name)
+
+	token, err := tokenRetriever.GetToken(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	saConfig.BearerToken = token
+
+	return NewForConfig(&saConfig)
 }
 
 func setConfigDefaults(config *restclient.Config) error {
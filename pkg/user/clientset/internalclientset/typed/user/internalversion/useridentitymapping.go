@@ -0,0 +1,87 @@
+package internalversion
+
+import (
+	api "github.com/openshift/origin/pkg/user/api"
+	kapi "k8s.io/kubernetes/pkg/api"
+	restclient "k8s.io/kubernetes/pkg/client/restclient"
+)
+
+// UserIdentityMappingsGetter has a method to return a UserIdentityMappingResourceInterface.
+// A user identity mapping's client should implement this interface.
+//
+// UserIdentityMapping is cluster-scoped, so unlike namespaced resources there
+// is no namespace to scope the returned interface to.
+type UserIdentityMappingsGetter interface {
+	UserIdentityMappings() UserIdentityMappingResourceInterface
+}
+
+// UserIdentityMappingResourceInterface has methods to work with
+// UserIdentityMapping resources.
+//
+// UserIdentityMapping is a virtual resource: each object is the single
+// mapping between one Identity and one User, named for the identity it maps.
+// It is not backed by its own stored collection, so unlike the other
+// resources in this package it has no List or Watch.
+type UserIdentityMappingResourceInterface interface {
+	Create(*api.UserIdentityMapping) (*api.UserIdentityMapping, error)
+	Update(*api.UserIdentityMapping) (*api.UserIdentityMapping, error)
+	Delete(name string, options *kapi.DeleteOptions) error
+	Get(name string) (*api.UserIdentityMapping, error)
+	UserIdentityMappingExpansion
+}
+
+// userIdentityMappings implements UserIdentityMappingResourceInterface
+type userIdentityMappings struct {
+	client restclient.Interface
+}
+
+// newUserIdentityMappings returns a UserIdentityMappings
+func newUserIdentityMappings(c *UserClient) *userIdentityMappings {
+	return &userIdentityMappings{
+		client: c.RESTClient(),
+	}
+}
+
+// Create takes the representation of a userIdentityMapping and creates it.  Returns the server's representation of the userIdentityMapping, and an error, if there is any.
+func (c *userIdentityMappings) Create(mapping *api.UserIdentityMapping) (result *api.UserIdentityMapping, err error) {
+	result = &api.UserIdentityMapping{}
+	err = c.client.Post().
+		Resource("useridentitymappings").
+		Body(mapping).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a userIdentityMapping and updates it. Returns the server's representation of the userIdentityMapping, and an error, if there is any.
+func (c *userIdentityMappings) Update(mapping *api.UserIdentityMapping) (result *api.UserIdentityMapping, err error) {
+	result = &api.UserIdentityMapping{}
+	err = c.client.Put().
+		Resource("useridentitymappings").
+		Name(mapping.Name).
+		Body(mapping).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the userIdentityMapping and deletes it. Returns an error if one occurs.
+func (c *userIdentityMappings) Delete(name string, options *kapi.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("useridentitymappings").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Get takes name of the userIdentityMapping, and returns the corresponding userIdentityMapping object, and an error if there is any.
+func (c *userIdentityMappings) Get(name string) (result *api.UserIdentityMapping, err error) {
+	result = &api.UserIdentityMapping{}
+	err = c.client.Get().
+		Resource("useridentitymappings").
+		Name(name).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,122 @@
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+
+	kerrors "k8s.io/kubernetes/pkg/api/errors"
+
+	"k8s.io/kubernetes/pkg/api"
+	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset"
+)
+
+// NamespaceMergeStrategy controls how EnsureOpts.Annotations/Labels are reconciled
+// against whatever a namespace already has.
+type NamespaceMergeStrategy string
+
+const (
+	// NamespaceMergeReplace makes the namespace's annotations (or labels) exactly
+	// equal to the requested map, deleting any existing key not present in it.
+	NamespaceMergeReplace NamespaceMergeStrategy = "Replace"
+	// NamespaceMergeOverwrite sets every requested key, overwriting an existing
+	// value if present, and leaves every other existing key untouched. This is the
+	// default and matches what the old ResourceVersion-clearing Update() calls did
+	// in practice.
+	NamespaceMergeOverwrite NamespaceMergeStrategy = "MergeOverwrite"
+	// NamespaceMergeKeepExisting sets a requested key only if it is not already
+	// present, so callers can seed a default without clobbering whatever is there.
+	NamespaceMergeKeepExisting NamespaceMergeStrategy = "MergeKeepExisting"
+)
+
+// EnsureOpts configures EnsureNamespace.
+type EnsureOpts struct {
+	Annotations   map[string]string
+	Labels        map[string]string
+	MergeStrategy NamespaceMergeStrategy
+
+	// DeleteAnnotations and DeleteLabels name keys to remove outright, regardless of
+	// MergeStrategy. None of the merge strategies can express "delete this one key"
+	// on their own -- NamespaceMergeReplace deletes everything not in the desired
+	// map, and the others never delete -- so callers that need to retract a single
+	// previously-set key list it here instead.
+	DeleteAnnotations []string
+	DeleteLabels      []string
+}
+
+// EnsureNamespace creates the namespace "name" if it does not already exist, then
+// reconciles its annotations and labels against opts according to opts.MergeStrategy,
+// and returns the resulting namespace. It replaces the old pattern scattered across
+// this package of clearing ObjectMeta.ResourceVersion and calling Update(), which
+// races any other actor writing namespace metadata concurrently (the namespace
+// controller, quota, SCC and node-selector admission all write namespace annotations
+// too) and silently discards their changes. EnsureNamespace only ever patches the
+// specific keys the caller asked for, via a merge patch computed from the namespace's
+// current state, so it is safe to call repeatedly and from multiple goroutines. Use
+// opts.DeleteAnnotations/DeleteLabels to retract a single key outright.
+func EnsureNamespace(clientset clientset.Interface, name string, opts EnsureOpts) (*api.Namespace, error) {
+	ns, err := clientset.Core().Namespaces().Get(name)
+	if kerrors.IsNotFound(err) {
+		ns, err = clientset.Core().Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{Name: name}})
+		if err != nil {
+			return nil, fmt.Errorf("creating namespace %s: %v", name, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("getting namespace %s: %v", name, err)
+	}
+
+	annotationPatch := namespaceMetadataPatch(ns.Annotations, opts.Annotations, opts.MergeStrategy)
+	for _, k := range opts.DeleteAnnotations {
+		annotationPatch[k] = nil
+	}
+	labelPatch := namespaceMetadataPatch(ns.Labels, opts.Labels, opts.MergeStrategy)
+	for _, k := range opts.DeleteLabels {
+		labelPatch[k] = nil
+	}
+	if len(annotationPatch) == 0 && len(labelPatch) == 0 {
+		return ns, nil
+	}
+
+	metadata := map[string]interface{}{}
+	if len(annotationPatch) > 0 {
+		metadata["annotations"] = annotationPatch
+	}
+	if len(labelPatch) > 0 {
+		metadata["labels"] = labelPatch
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return nil, err
+	}
+
+	return clientset.Core().Namespaces().Patch(name, api.StrategicMergePatchType, patch)
+}
+
+// namespaceMetadataPatch computes the merge-patch fragment for one of Annotations or
+// Labels: a map of key -> new value, with key -> nil meaning "delete this key" (the
+// merge patch convention both api.StrategicMergePatchType and plain JSON Merge Patch
+// use for map-typed fields like these).
+func namespaceMetadataPatch(current, desired map[string]string, strategy NamespaceMergeStrategy) map[string]interface{} {
+	patch := map[string]interface{}{}
+	switch strategy {
+	case NamespaceMergeReplace:
+		for k := range current {
+			if _, ok := desired[k]; !ok {
+				patch[k] = nil
+			}
+		}
+		for k, v := range desired {
+			patch[k] = v
+		}
+	case NamespaceMergeKeepExisting:
+		for k, v := range desired {
+			if _, exists := current[k]; !exists {
+				patch[k] = v
+			}
+		}
+	default: // NamespaceMergeOverwrite, and the zero value
+		for k, v := range desired {
+			patch[k] = v
+		}
+	}
+	return patch
+}
@@ -0,0 +1,305 @@
+package networking
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/util/wait"
+	"k8s.io/kubernetes/test/e2e/framework"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// PropagationRules selects which parent annotation/label keys get mirrored onto a
+// propagator's registered child namespaces.
+type PropagationRules struct {
+	AnnotationKeys []*regexp.Regexp
+	LabelKeys      []*regexp.Regexp
+}
+
+func matchesAny(patterns []*regexp.Regexp, key string) bool {
+	for _, p := range patterns {
+		if p.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// propagationRegistry guards against a namespace being registered as both a parent
+// and a child, which would make the one-level propagation model ambiguous.
+var (
+	propagationRegistryMu sync.Mutex
+	propagationParents    = map[string]bool{}
+	propagationChildren   = map[string]bool{}
+)
+
+// NamespacePropagator mirrors a parent namespace's matching annotations/labels onto a
+// set of registered child namespaces, similar to Accurate's subnamespaces: a test
+// declares a parent with PropagationRules, registers children via RegisterChild (which
+// also performs the child's initial sync, so it inherits matching keys immediately
+// rather than waiting for the parent to change again), and a background reconciler
+// keeps each child's matching keys in sync thereafter, using EnsureNamespace (the same
+// patch-based primitive every other namespace mutation in this package goes through).
+// Server-Side Apply tracks per-field-manager ownership so a field manager's deleted
+// key is removed even if other managers also wrote to the object; a merge patch has no
+// such concept. Instead, NamespacePropagator tracks per child the set of keys it last
+// propagated and explicitly deletes any of its own previously-propagated keys that no
+// longer match the rules on the parent -- equivalent to SSA ownership for this one
+// writer, though it offers no protection if some other actor writes the same key.
+type NamespacePropagator struct {
+	f      *framework.Framework
+	parent string
+	rules  PropagationRules
+
+	mu         sync.Mutex
+	children   map[string]bool
+	propagated map[string]*propagatedKeys
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// propagatedKeys is the set of annotation/label keys NamespacePropagator last wrote to
+// one child, so a later sync can tell which of its own keys stopped matching and
+// should be deleted rather than just left stale.
+type propagatedKeys struct {
+	annotations map[string]bool
+	labels      map[string]bool
+}
+
+// NewNamespacePropagator registers parentName as a propagation parent and starts a
+// reconciler goroutine that watches it and mirrors matching keys onto every namespace
+// later passed to RegisterChild. Call Stop when the test is done to release the watch.
+func NewNamespacePropagator(f *framework.Framework, parentName string, rules PropagationRules) (*NamespacePropagator, error) {
+	propagationRegistryMu.Lock()
+	if propagationChildren[parentName] {
+		propagationRegistryMu.Unlock()
+		return nil, fmt.Errorf("namespace %s is already registered as a propagation child", parentName)
+	}
+	propagationParents[parentName] = true
+	propagationRegistryMu.Unlock()
+
+	p := &NamespacePropagator{
+		f:          f,
+		parent:     parentName,
+		rules:      rules,
+		children:   map[string]bool{},
+		propagated: map[string]*propagatedKeys{},
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+	go p.reconcileLoop()
+	return p, nil
+}
+
+// RegisterChild adds childName as a propagation target and immediately syncs the
+// parent's current matching keys onto it, so the child inherits them on registration
+// rather than waiting for the next change to the parent. It is an error to register a
+// namespace that is itself a registered parent (or the propagator's own parent) --
+// propagation chains are intentionally one level deep.
+func (p *NamespacePropagator) RegisterChild(childName string) error {
+	propagationRegistryMu.Lock()
+	if propagationParents[childName] {
+		propagationRegistryMu.Unlock()
+		return fmt.Errorf("namespace %s is already registered as a propagation parent", childName)
+	}
+	if p.parent == childName {
+		propagationRegistryMu.Unlock()
+		return fmt.Errorf("namespace %s cannot propagate to itself", childName)
+	}
+	propagationChildren[childName] = true
+	propagationRegistryMu.Unlock()
+
+	p.mu.Lock()
+	p.children[childName] = true
+	p.mu.Unlock()
+
+	parent, err := p.f.ClientSet.Core().Namespaces().Get(p.parent)
+	if err != nil {
+		return fmt.Errorf("getting parent namespace %s: %v", p.parent, err)
+	}
+	annotations, labels := p.matchingKeys(parent)
+	if err := p.syncChild(childName, annotations, labels); err != nil {
+		return fmt.Errorf("initial sync of child %s: %v", childName, err)
+	}
+	return nil
+}
+
+// Stop releases the parent watch and waits for the reconciler goroutine to exit.
+func (p *NamespacePropagator) Stop() {
+	close(p.stopCh)
+	<-p.doneCh
+
+	propagationRegistryMu.Lock()
+	delete(propagationParents, p.parent)
+	p.mu.Lock()
+	for child := range p.children {
+		delete(propagationChildren, child)
+	}
+	p.mu.Unlock()
+	propagationRegistryMu.Unlock()
+}
+
+func (p *NamespacePropagator) reconcileLoop() {
+	defer close(p.doneCh)
+
+	w, err := p.f.ClientSet.Core().Namespaces().Watch(api.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", p.parent),
+	})
+	if err != nil {
+		framework.Logf("namespace propagation: unable to watch parent %s: %v", p.parent, err)
+		return
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			ns, ok := event.Object.(*api.Namespace)
+			if !ok {
+				continue
+			}
+			p.reconcile(ns)
+		}
+	}
+}
+
+// reconcile diffs parent's current annotations/labels against the propagation rules
+// and syncs the matching set onto every registered child.
+func (p *NamespacePropagator) reconcile(parent *api.Namespace) {
+	annotations, labels := p.matchingKeys(parent)
+
+	p.mu.Lock()
+	children := make([]string, 0, len(p.children))
+	for c := range p.children {
+		children = append(children, c)
+	}
+	p.mu.Unlock()
+
+	for _, child := range children {
+		if err := p.syncChild(child, annotations, labels); err != nil {
+			framework.Logf("namespace propagation: unable to sync %s -> %s: %v", p.parent, child, err)
+		}
+	}
+}
+
+// matchingKeys extracts the subset of parent's annotations/labels that the
+// propagation rules select.
+func (p *NamespacePropagator) matchingKeys(parent *api.Namespace) (annotations, labels map[string]string) {
+	annotations = map[string]string{}
+	for k, v := range parent.Annotations {
+		if matchesAny(p.rules.AnnotationKeys, k) {
+			annotations[k] = v
+		}
+	}
+	labels = map[string]string{}
+	for k, v := range parent.Labels {
+		if matchesAny(p.rules.LabelKeys, k) {
+			labels[k] = v
+		}
+	}
+	return annotations, labels
+}
+
+// syncChild merge-patches annotations/labels onto child via EnsureNamespace, deleting
+// any key this propagator previously set on child that is no longer present in
+// annotations/labels.
+func (p *NamespacePropagator) syncChild(child string, annotations, labels map[string]string) error {
+	p.mu.Lock()
+	prev := p.propagated[child]
+	var deleteAnnotations, deleteLabels []string
+	if prev != nil {
+		for k := range prev.annotations {
+			if _, ok := annotations[k]; !ok {
+				deleteAnnotations = append(deleteAnnotations, k)
+			}
+		}
+		for k := range prev.labels {
+			if _, ok := labels[k]; !ok {
+				deleteLabels = append(deleteLabels, k)
+			}
+		}
+	}
+	p.mu.Unlock()
+
+	_, err := EnsureNamespace(p.f.ClientSet, child, EnsureOpts{
+		Annotations:       annotations,
+		Labels:            labels,
+		MergeStrategy:     NamespaceMergeOverwrite,
+		DeleteAnnotations: deleteAnnotations,
+		DeleteLabels:      deleteLabels,
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.propagated[child] = &propagatedKeys{annotations: keySet(annotations), labels: keySet(labels)}
+	p.mu.Unlock()
+	return nil
+}
+
+// keySet returns the set of keys in m.
+func keySet(m map[string]string) map[string]bool {
+	s := make(map[string]bool, len(m))
+	for k := range m {
+		s[k] = true
+	}
+	return s
+}
+
+var _ = Describe("Namespace annotation/label propagation", func() {
+	f := framework.NewDefaultFramework("namespace-propagation")
+
+	It("should sync a child on registration and remove keys that stop matching the parent [Feature:NetworkPolicy]", func() {
+		parent := f.Namespace
+		child, err := f.CreateNamespace("nspropagation-child", map[string]string{})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("Seeding a matching annotation on the parent before any child is registered.")
+		_, err = EnsureNamespace(f.ClientSet, parent.Name, EnsureOpts{
+			Annotations:   map[string]string{"propagate.example.com/team": "netpol"},
+			MergeStrategy: NamespaceMergeOverwrite,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		propagator, err := NewNamespacePropagator(f, parent.Name, PropagationRules{
+			AnnotationKeys: []*regexp.Regexp{regexp.MustCompile(`^propagate\.example\.com/`)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		defer propagator.Stop()
+
+		By("Registering the child should inherit the parent's already-set annotation immediately.")
+		Expect(propagator.RegisterChild(child.Name)).To(Succeed())
+		updated, err := f.ClientSet.Core().Namespaces().Get(child.Name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Annotations["propagate.example.com/team"]).To(Equal("netpol"))
+
+		By("Removing the annotation from the parent should remove it from the child too.")
+		_, err = EnsureNamespace(f.ClientSet, parent.Name, EnsureOpts{
+			DeleteAnnotations: []string{"propagate.example.com/team"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = wait.Poll(time.Second, 30*time.Second, func() (bool, error) {
+			ns, err := f.ClientSet.Core().Namespaces().Get(child.Name)
+			if err != nil {
+				return false, err
+			}
+			_, present := ns.Annotations["propagate.example.com/team"]
+			return !present, nil
+		})
+		Expect(err).NotTo(HaveOccurred(), "propagated annotation should eventually be removed from the child")
+	})
+})
@@ -24,6 +24,8 @@ import (
 	"k8s.io/kubernetes/test/e2e/framework"
 
 	"fmt"
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -75,7 +77,7 @@ var _ = Describe("NetworkPolicy", func() {
 			testCanConnect(f, ns, "client-can-connect", service, 80)
 
 			framework.Logf("Enabling network isolation.")
-			setNamespaceIsolation(f, ns, "DefaultDeny")
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
 
 			// Create a pod with name 'client-b', which will attempt to comunicate with the server,
 			// but should not be able to now that isolation is on.
@@ -84,7 +86,7 @@ var _ = Describe("NetworkPolicy", func() {
 
 		It("should enforce policy based on PodSelector [Feature:NetworkPolicy]", func() {
 			ns := f.Namespace
-			setNamespaceIsolation(f, ns, "DefaultDeny")
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
 
 			By("Creating a simple server.")
 			serverPod, service := createServerPodAndService(f, ns, "server", []int{80})
@@ -171,7 +173,7 @@ var _ = Describe("NetworkPolicy", func() {
 			testCanConnect(f, ns, "basecase-reachable-80", service, 80)
 			testCanConnect(f, ns, "basecase-reachable-81", service, 81)
 
-			setNamespaceIsolation(f, ns, "DefaultDeny")
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
 
 			By("Testing pods cannot by default when isolation is turned on.")
 			testCannotConnect(f, ns, "basecase-unreachable-80", service, 80)
@@ -297,7 +299,7 @@ var _ = Describe("NetworkPolicy", func() {
 			testCanConnect(f, ns, "test-a", service, 80)
 			testCanConnect(f, ns, "test-b", service, 81)
 
-			setNamespaceIsolation(f, ns, "DefaultDeny")
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
 
 			By("Testing pods cannot connect to either port when no policy is defined.")
 			testCannotConnect(f, ns, "test-a-2", service, 80)
@@ -393,7 +395,7 @@ var _ = Describe("NetworkPolicy", func() {
 			testCanConnect(f, ns, "test-a", service, 80)
 			testCanConnect(f, ns, "test-b", service, 81)
 
-			setNamespaceIsolation(f, ns, "DefaultDeny")
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
 
 			By("Testing pods cannot connect to either port when isolation is on.")
 			testCannotConnect(f, ns, "test-a", service, 80)
@@ -436,7 +438,7 @@ var _ = Describe("NetworkPolicy", func() {
 				"ns-name": nsBName,
 			})
 			Expect(err).NotTo(HaveOccurred())
-			setNamespaceIsolation(f, nsA, "DefaultDeny")
+			setNamespaceIsolation(f, nsA, "DefaultDeny", "")
 
 			// Create Server with Service in NS-B
 			By("Creating a webserver tied to a service.")
@@ -495,12 +497,441 @@ var _ = Describe("NetworkPolicy", func() {
 			testCannotConnect(f, nsA, "client-a", service, 80)
 			testCanConnect(f, nsB, "client-b", service, 80)
 		})
+
+		It("should support setting DefaultDeny namespace egress policy [Feature:NetworkPolicy]", func() {
+			ns := f.Namespace
+
+			By("Create a simple server.")
+			podServer, service := createServerPodAndService(f, ns, "server", []int{80})
+			defer func() {
+				By("Cleaning up the server.")
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(podServer.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", podServer.Name, err)
+				}
+			}()
+			defer func() {
+				By("Cleaning up the server's service.")
+				if err := f.ClientSet.Core().Services(ns.Name).Delete(service.Name, nil); err != nil {
+					framework.Failf("unable to cleanup svc %v: %v", service.Name, err)
+				}
+			}()
+			framework.Logf("Waiting for Server to come up.")
+			err := framework.WaitForPodRunningInNamespace(f.ClientSet, podServer)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Creating client which will be able to contact the server since egress isolation is off.")
+			testCanConnect(f, ns, "client-can-connect", service, 80)
+
+			framework.Logf("Enabling egress network isolation.")
+			setNamespaceIsolation(f, ns, "", "DefaultDeny")
+
+			By("Creating a client which should not be able to reach the server now that egress isolation is on.")
+			testCannotConnect(f, ns, "client-cannot-connect", service, 80)
+		})
+
+		It("should enforce egress policy based on PodSelector [Feature:NetworkPolicy]", func() {
+			ns := f.Namespace
+			setNamespaceIsolation(f, ns, "", "DefaultDeny")
+
+			By("Creating two servers, one of which the client is allowed to reach.")
+			allowedServerPod, allowedService := createServerPodAndService(f, ns, "server-allowed", []int{80})
+			defer func() {
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(allowedServerPod.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", allowedServerPod.Name, err)
+				}
+			}()
+			defer func() {
+				if err := f.ClientSet.Core().Services(ns.Name).Delete(allowedService.Name, nil); err != nil {
+					framework.Failf("unable to cleanup svc %v: %v", allowedService.Name, err)
+				}
+			}()
+			deniedServerPod, deniedService := createServerPodAndService(f, ns, "server-denied", []int{80})
+			defer func() {
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(deniedServerPod.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", deniedServerPod.Name, err)
+				}
+			}()
+			defer func() {
+				if err := f.ClientSet.Core().Services(ns.Name).Delete(deniedService.Name, nil); err != nil {
+					framework.Failf("unable to cleanup svc %v: %v", deniedService.Name, err)
+				}
+			}()
+			framework.Logf("Waiting for servers to come up.")
+			Expect(framework.WaitForPodRunningInNamespace(f.ClientSet, allowedServerPod)).NotTo(HaveOccurred())
+			Expect(framework.WaitForPodRunningInNamespace(f.ClientSet, deniedServerPod)).NotTo(HaveOccurred())
+
+			By("Creating a network policy for client-a which allows egress only to server-allowed.")
+			policy := extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{
+					Name: "allow-egress-to-server-allowed",
+				},
+				Spec: extensions.NetworkPolicySpec{
+					// Apply this policy to the isolated client pod.
+					PodSelector: unversioned.LabelSelector{
+						MatchLabels: map[string]string{
+							"pod-name": "client-a",
+						},
+					},
+					PolicyTypes: []extensions.PolicyType{extensions.PolicyTypeEgress},
+					Egress: []extensions.NetworkPolicyEgressRule{{
+						To: []extensions.NetworkPolicyPeer{{
+							PodSelector: &unversioned.LabelSelector{
+								MatchLabels: map[string]string{
+									"pod-name": allowedServerPod.Name,
+								},
+							},
+						}},
+					}},
+				},
+			}
+			_, err := f.ClientSet.Extensions().NetworkPolicies(ns.Name).Create(&policy)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				if err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Delete(policy.Name, nil); err != nil {
+					framework.Failf("unable to cleanup policy %v: %v", policy.Name, err)
+				}
+			}()
+
+			testCanConnect(f, ns, "client-a", allowedService, 80)
+			testCannotConnect(f, ns, "client-a", deniedService, 80)
+		})
+
+		It("should enforce egress policy based on Ports [Feature:NetworkPolicy]", func() {
+			ns := f.Namespace
+			setNamespaceIsolation(f, ns, "", "DefaultDeny")
+
+			By("Creating a simple server with two ports.")
+			serverPod, service := createServerPodAndService(f, ns, "server", []int{80, 81})
+			defer func() {
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(serverPod.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", serverPod.Name, err)
+				}
+			}()
+			defer func() {
+				if err := f.ClientSet.Core().Services(ns.Name).Delete(service.Name, nil); err != nil {
+					framework.Failf("unable to cleanup svc %v: %v", service.Name, err)
+				}
+			}()
+			framework.Logf("Waiting for Server to come up.")
+			err := framework.WaitForPodRunningInNamespace(f.ClientSet, serverPod)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Creating a network policy for client-a which allows egress only to port 81.")
+			policy := extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{
+					Name: "allow-egress-on-port-81",
+				},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: unversioned.LabelSelector{
+						MatchLabels: map[string]string{
+							"pod-name": "client-a",
+						},
+					},
+					PolicyTypes: []extensions.PolicyType{extensions.PolicyTypeEgress},
+					Egress: []extensions.NetworkPolicyEgressRule{{
+						Ports: []extensions.NetworkPolicyPort{{
+							Port: &intstr.IntOrString{IntVal: 81},
+						}},
+					}},
+				},
+			}
+			_, err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Create(&policy)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				if err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Delete(policy.Name, nil); err != nil {
+					framework.Failf("unable to cleanup policy %v: %v", policy.Name, err)
+				}
+			}()
+
+			testCannotConnect(f, ns, "client-a", service, 80)
+			testCanConnect(f, ns, "client-a", service, 81)
+		})
+
+		// NOTE: this exercises ipBlock/except enforcement end-to-end against whatever
+		// NetworkPolicy backend the cluster under test is running; it does not itself
+		// carry the openshift-sdn plugin changes needed to translate ipBlock/except
+		// into OVS flows, which live outside this test package.
+		It("should enforce policy based on ipBlock with an except list [Feature:NetworkPolicy]", func() {
+			ns := f.Namespace
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
+
+			By("Creating a simple server.")
+			serverPod, service := createServerPodAndService(f, ns, "server", []int{80})
+			defer func() {
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(serverPod.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", serverPod.Name, err)
+				}
+			}()
+			defer func() {
+				if err := f.ClientSet.Core().Services(ns.Name).Delete(service.Name, nil); err != nil {
+					framework.Failf("unable to cleanup svc %v: %v", service.Name, err)
+				}
+			}()
+			framework.Logf("Waiting for Server to come up.")
+			err := framework.WaitForPodRunningInNamespace(f.ClientSet, serverPod)
+			Expect(err).NotTo(HaveOccurred())
+
+			nodes := framework.GetReadySchedulableNodesOrDie(f.ClientSet)
+			Expect(len(nodes.Items)).To(BeNumerically(">", 0))
+			node := nodes.Items[0]
+			nodeIP := ""
+			for _, addr := range node.Status.Addresses {
+				if addr.Type == api.NodeInternalIP {
+					nodeIP = addr.Address
+					break
+				}
+			}
+			Expect(nodeIP).NotTo(BeEmpty())
+			clientCIDR := fmt.Sprintf("%s/32", nodeIP)
+
+			By(fmt.Sprintf("Creating a network policy which allows traffic only from %s, excepting the client's node address %s.", "10.0.0.0/8", nodeIP))
+			policy := extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{
+					Name: "allow-ipblock-except-client",
+				},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: unversioned.LabelSelector{
+						MatchLabels: map[string]string{
+							"pod-name": serverPod.Name,
+						},
+					},
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						From: []extensions.NetworkPolicyPeer{{
+							IPBlock: &extensions.IPBlock{
+								CIDR:   "10.0.0.0/8",
+								Except: []string{clientCIDR},
+							},
+						}},
+					}},
+				},
+			}
+			_, err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Create(&policy)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				if err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Delete(policy.Name, nil); err != nil {
+					framework.Failf("unable to cleanup policy %v: %v", policy.Name, err)
+				}
+			}()
+
+			By("A client address inside the CIDR but outside the except list should still be allowed.")
+			testCanConnect(f, ns, "client-in-cidr", service, 80)
+
+			By("The excepted client address should still be denied.")
+			testCannotConnectFromHost(f, ns, "client-excepted", service, 80, true, node.Name)
+		})
+
+		It("should enforce policy based on a Port/EndPort range [Feature:NetworkPolicy]", func() {
+			ns := f.Namespace
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
+
+			const rangeStart, rangeEnd = 8000, 8100
+
+			By("Creating a server that listens on every port in the range plus one port outside it.")
+			rangePorts := []int{}
+			for port := rangeStart; port <= rangeEnd; port++ {
+				rangePorts = append(rangePorts, port)
+			}
+			rangePorts = append(rangePorts, rangeEnd+1)
+			serverPod := createRangePortServerPod(f, ns, "server", rangePorts...)
+			defer func() {
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(serverPod.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", serverPod.Name, err)
+				}
+			}()
+			framework.Logf("Waiting for Server to come up.")
+			err := framework.WaitForPodRunningInNamespace(f.ClientSet, serverPod)
+			Expect(err).NotTo(HaveOccurred())
+			serverPod, err = f.ClientSet.Core().Pods(ns.Name).Get(serverPod.Name)
+			Expect(err).NotTo(HaveOccurred())
+
+			By(fmt.Sprintf("Creating a network policy which allows ingress only to ports %d-%d.", rangeStart, rangeEnd))
+			policy := extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{
+					Name: "allow-ingress-on-port-range",
+				},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: unversioned.LabelSelector{
+						MatchLabels: map[string]string{
+							"pod-name": serverPod.Name,
+						},
+					},
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						Ports: []extensions.NetworkPolicyPort{{
+							Port:    &intstr.IntOrString{IntVal: rangeStart},
+							EndPort: rangeEnd,
+						}},
+					}},
+				},
+			}
+			_, err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Create(&policy)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				if err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Delete(policy.Name, nil); err != nil {
+					framework.Failf("unable to cleanup policy %v: %v", policy.Name, err)
+				}
+			}()
+
+			testCanConnectIP(f, ns, "client-range-start", serverPod.Status.PodIP, rangeStart)
+			testCanConnectIP(f, ns, "client-range-end", serverPod.Status.PodIP, rangeEnd)
+			testCannotConnectIP(f, ns, "client-outside-range", serverPod.Status.PodIP, rangeEnd+1)
+		})
+
+		// NOTE: this assumes the apiserver validates EndPort >= Port on admission and
+		// that the cluster's NetworkPolicy backend can emit a range match for allowed
+		// ports; neither the admission validation nor the openshift-sdn/OVN range
+		// translation live in this test package, so a cluster without them will fail
+		// this suite rather than this test silently passing.
+		It("should reject a NetworkPolicyPort with EndPort less than Port [Feature:NetworkPolicy]", func() {
+			ns := f.Namespace
+			policy := extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{
+					Name: "invalid-port-range",
+				},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: unversioned.LabelSelector{},
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						Ports: []extensions.NetworkPolicyPort{{
+							Port:    &intstr.IntOrString{IntVal: 100},
+							EndPort: 50,
+						}},
+					}},
+				},
+			}
+			_, err := f.ClientSet.Extensions().NetworkPolicies(ns.Name).Create(&policy)
+			Expect(err).To(HaveOccurred(), "admission should reject EndPort < Port")
+		})
+
+		It("should enforce policy based on Protocol [Feature:NetworkPolicy]", func() {
+			ns := f.Namespace
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
+
+			By("Creating a server that listens on the same port over both TCP and UDP.")
+			serverPod, service := createServerPodAndServiceProtocol(f, ns, "server", []int{80, 80}, []api.Protocol{api.ProtocolTCP, api.ProtocolUDP})
+			defer func() {
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(serverPod.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", serverPod.Name, err)
+				}
+			}()
+			defer func() {
+				if err := f.ClientSet.Core().Services(ns.Name).Delete(service.Name, nil); err != nil {
+					framework.Failf("unable to cleanup svc %v: %v", service.Name, err)
+				}
+			}()
+			framework.Logf("Waiting for Server to come up.")
+			err := framework.WaitForPodRunningInNamespace(f.ClientSet, serverPod)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Creating a network policy which allows ingress only on TCP/80.")
+			policy := extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{
+					Name: "allow-ingress-tcp-80-only",
+				},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: unversioned.LabelSelector{
+						MatchLabels: map[string]string{
+							"pod-name": serverPod.Name,
+						},
+					},
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						Ports: []extensions.NetworkPolicyPort{{
+							Protocol: protocolPtr(api.ProtocolTCP),
+							Port:     &intstr.IntOrString{IntVal: 80},
+						}},
+					}},
+				},
+			}
+			_, err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Create(&policy)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				if err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Delete(policy.Name, nil); err != nil {
+					framework.Failf("unable to cleanup policy %v: %v", policy.Name, err)
+				}
+			}()
+
+			testCanConnectProtocol(f, ns, "client-tcp", service, 80, api.ProtocolTCP)
+			testCannotConnectProtocol(f, ns, "client-udp", service, 80, api.ProtocolUDP)
+		})
+
+		It("should enforce policy based on a named port [Feature:NetworkPolicy]", func() {
+			ns := f.Namespace
+			setNamespaceIsolation(f, ns, "DefaultDeny", "")
+
+			const groupLabel = "named-port-group"
+			portName := fmt.Sprintf("serve-80-%s", strings.ToLower(string(api.ProtocolTCP)))
+
+			By("Creating two servers that expose the same named port on different underlying port numbers, so the policy can only pass if it resolves the name per-pod rather than once for the whole group.")
+			serverA, serviceA := createNamedPortServerPod(f, ns, "server-a", groupLabel, portName, 80, 8080)
+			defer func() {
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(serverA.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", serverA.Name, err)
+				}
+			}()
+			defer func() {
+				if err := f.ClientSet.Core().Services(ns.Name).Delete(serviceA.Name, nil); err != nil {
+					framework.Failf("unable to cleanup svc %v: %v", serviceA.Name, err)
+				}
+			}()
+			serverB, serviceB := createNamedPortServerPod(f, ns, "server-b", groupLabel, portName, 81, 8081)
+			defer func() {
+				if err := f.ClientSet.Core().Pods(ns.Name).Delete(serverB.Name, nil); err != nil {
+					framework.Failf("unable to cleanup pod %v: %v", serverB.Name, err)
+				}
+			}()
+			defer func() {
+				if err := f.ClientSet.Core().Services(ns.Name).Delete(serviceB.Name, nil); err != nil {
+					framework.Failf("unable to cleanup svc %v: %v", serviceB.Name, err)
+				}
+			}()
+			framework.Logf("Waiting for servers to come up.")
+			Expect(framework.WaitForPodRunningInNamespace(f.ClientSet, serverA)).NotTo(HaveOccurred())
+			Expect(framework.WaitForPodRunningInNamespace(f.ClientSet, serverB)).NotTo(HaveOccurred())
+
+			By(fmt.Sprintf("Creating a network policy for the group which allows ingress to named port %q.", portName))
+			policy := extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{
+					Name: "allow-ingress-on-named-port",
+				},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: unversioned.LabelSelector{
+						MatchLabels: map[string]string{
+							groupLabel: "named-port-group",
+						},
+					},
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						Ports: []extensions.NetworkPolicyPort{{
+							Port: &intstr.IntOrString{Type: intstr.String, StrVal: portName},
+						}},
+					}},
+				},
+			}
+			_, err := f.ClientSet.Extensions().NetworkPolicies(ns.Name).Create(&policy)
+			Expect(err).NotTo(HaveOccurred())
+			defer func() {
+				if err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Delete(policy.Name, nil); err != nil {
+					framework.Failf("unable to cleanup policy %v: %v", policy.Name, err)
+				}
+			}()
+
+			By("The named port should resolve to 80 on server-a and 81 on server-b, not the same literal number for both.")
+			testCanConnect(f, ns, "client-a-named", serviceA, 80)
+			testCanConnect(f, ns, "client-b-named", serviceB, 81)
+
+			By("The extra, differently-named port on server-a is not covered by the policy's named-port rule and must still be denied.")
+			testCannotConnect(f, ns, "client-a-extra", serviceA, 8080)
+		})
 	})
 })
 
 func testCanConnect(f *framework.Framework, ns *api.Namespace, podName string, service *api.Service, targetPort int) {
+	testCanConnectFromHost(f, ns, podName, service, targetPort, false, "")
+}
+
+// testCanConnectFromHost is like testCanConnect, but when fromHost is true the client
+// pod runs with hostNetwork so it probes from a known node IP -- this lets a test
+// exercise an ipBlock peer against a specific source address.
+func testCanConnectFromHost(f *framework.Framework, ns *api.Namespace, podName string, service *api.Service, targetPort int, fromHost bool, nodeName string) {
 	By(fmt.Sprintf("Creating client pod %s that should successfully connect to %s.", podName, service.Name))
-	podClient := createNetworkClientPod(f, ns, podName, service.Spec.ClusterIP, targetPort)
+	podClient := createNetworkClientPod(f, ns, podName, service.Spec.ClusterIP, targetPort, fromHost, nodeName)
 	defer func() {
 		By(fmt.Sprintf("Cleaning up the pod %s", podName))
 		if err := f.ClientSet.Core().Pods(ns.Name).Delete(podClient.Name, nil); err != nil {
@@ -518,8 +949,14 @@ func testCanConnect(f *framework.Framework, ns *api.Namespace, podName string, s
 }
 
 func testCannotConnect(f *framework.Framework, ns *api.Namespace, podName string, service *api.Service, targetPort int) {
+	testCannotConnectFromHost(f, ns, podName, service, targetPort, false, "")
+}
+
+// testCannotConnectFromHost is like testCannotConnect, but when fromHost is true the
+// client pod runs with hostNetwork so it probes from a known node IP.
+func testCannotConnectFromHost(f *framework.Framework, ns *api.Namespace, podName string, service *api.Service, targetPort int, fromHost bool, nodeName string) {
 	By(fmt.Sprintf("Creating client pod %s that should not be able to connect to %s.", podName, service.Name))
-	podClient := createNetworkClientPod(f, ns, podName, service.Spec.ClusterIP, targetPort)
+	podClient := createNetworkClientPod(f, ns, podName, service.Spec.ClusterIP, targetPort, fromHost, nodeName)
 	defer func() {
 		By(fmt.Sprintf("Cleaning up the pod %s", podName))
 		if err := f.ClientSet.Core().Pods(ns.Name).Delete(podClient.Name, nil); err != nil {
@@ -532,31 +969,217 @@ func testCannotConnect(f *framework.Framework, ns *api.Namespace, podName string
 	Expect(err).To(HaveOccurred(), fmt.Sprintf("checking %s could not communicate with server.", podName))
 }
 
+// testCanConnectIP and testCannotConnectIP are like testCanConnect/testCannotConnect,
+// but address a pod IP directly instead of going through a Service -- used by the
+// EndPort range test where a single Service cannot represent a whole port range.
+func testCanConnectIP(f *framework.Framework, ns *api.Namespace, podName string, targetIP string, targetPort int) {
+	By(fmt.Sprintf("Creating client pod %s that should successfully connect to %s:%d.", podName, targetIP, targetPort))
+	podClient := createNetworkClientPod(f, ns, podName, targetIP, targetPort, false, "")
+	defer func() {
+		if err := f.ClientSet.Core().Pods(ns.Name).Delete(podClient.Name, nil); err != nil {
+			framework.Failf("unable to cleanup pod %v: %v", podClient.Name, err)
+		}
+	}()
+
+	err := framework.WaitForPodNoLongerRunningInNamespace(f.ClientSet, podClient.Name, ns.Name, "0")
+	Expect(err).NotTo(HaveOccurred(), "Pod did not finish as expected.")
+	err = framework.WaitForPodSuccessInNamespace(f.ClientSet, podClient.Name, ns.Name)
+	Expect(err).NotTo(HaveOccurred(), fmt.Sprintf("checking %s could communicate with server.", podClient.Name))
+}
+
+func testCannotConnectIP(f *framework.Framework, ns *api.Namespace, podName string, targetIP string, targetPort int) {
+	By(fmt.Sprintf("Creating client pod %s that should not be able to connect to %s:%d.", podName, targetIP, targetPort))
+	podClient := createNetworkClientPod(f, ns, podName, targetIP, targetPort, false, "")
+	defer func() {
+		if err := f.ClientSet.Core().Pods(ns.Name).Delete(podClient.Name, nil); err != nil {
+			framework.Failf("unable to cleanup pod %v: %v", podClient.Name, err)
+		}
+	}()
+
+	err := framework.WaitForPodSuccessInNamespace(f.ClientSet, podClient.Name, ns.Name)
+	Expect(err).To(HaveOccurred(), fmt.Sprintf("checking %s could not communicate with server.", podName))
+}
+
+// testCanConnectProtocol and testCannotConnectProtocol are like testCanConnect/
+// testCannotConnect, but probe over the given protocol instead of always TCP.
+func testCanConnectProtocol(f *framework.Framework, ns *api.Namespace, podName string, service *api.Service, targetPort int, protocol api.Protocol) {
+	By(fmt.Sprintf("Creating client pod %s that should successfully connect to %s over %s.", podName, service.Name, protocol))
+	podClient := createNetworkClientPodProtocol(f, ns, podName, service.Spec.ClusterIP, targetPort, protocol, false, "")
+	defer func() {
+		if err := f.ClientSet.Core().Pods(ns.Name).Delete(podClient.Name, nil); err != nil {
+			framework.Failf("unable to cleanup pod %v: %v", podClient.Name, err)
+		}
+	}()
+
+	err := framework.WaitForPodNoLongerRunningInNamespace(f.ClientSet, podClient.Name, ns.Name, "0")
+	Expect(err).NotTo(HaveOccurred(), "Pod did not finish as expected.")
+	err = framework.WaitForPodSuccessInNamespace(f.ClientSet, podClient.Name, ns.Name)
+	Expect(err).NotTo(HaveOccurred(), fmt.Sprintf("checking %s could communicate with server.", podClient.Name))
+}
+
+func testCannotConnectProtocol(f *framework.Framework, ns *api.Namespace, podName string, service *api.Service, targetPort int, protocol api.Protocol) {
+	By(fmt.Sprintf("Creating client pod %s that should not be able to connect to %s over %s.", podName, service.Name, protocol))
+	podClient := createNetworkClientPodProtocol(f, ns, podName, service.Spec.ClusterIP, targetPort, protocol, false, "")
+	defer func() {
+		if err := f.ClientSet.Core().Pods(ns.Name).Delete(podClient.Name, nil); err != nil {
+			framework.Failf("unable to cleanup pod %v: %v", podClient.Name, err)
+		}
+	}()
+
+	err := framework.WaitForPodSuccessInNamespace(f.ClientSet, podClient.Name, ns.Name)
+	Expect(err).To(HaveOccurred(), fmt.Sprintf("checking %s could not communicate with server.", podName))
+}
+
+// createNamedPortServerPod starts a server pod exposing a port named portName bound to
+// numberedPort, plus a second, differently-named port (portName + "-extra") bound to
+// extraPort that no named-port policy rule references. It labels the pod with both
+// "pod-name" (unique to podName) and groupLabel (shared across every pod in a
+// heterogeneous-port-name test group), so a single NetworkPolicy can select the whole
+// group by groupLabel while still letting each pod expose the same port name at a
+// different underlying port number.
+func createNamedPortServerPod(f *framework.Framework, namespace *api.Namespace, podName, groupLabel, portName string, numberedPort, extraPort int) (*api.Pod, *api.Service) {
+	By(fmt.Sprintf("Creating a server pod %s in namespace %s with named port %q on %d and an extra port %q on %d", podName, namespace.Name, portName, numberedPort, portName+"-extra", extraPort))
+	pod, err := f.ClientSet.Core().Pods(namespace.Name).Create(&api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name: podName,
+			Labels: map[string]string{
+				"pod-name": podName,
+				groupLabel: "named-port-group",
+			},
+		},
+		Spec: api.PodSpec{
+			RestartPolicy: api.RestartPolicyNever,
+			Containers: []api.Container{
+				{
+					Name:  fmt.Sprintf("%s-container-%d", podName, numberedPort),
+					Image: "gcr.io/google_containers/redis:e2e",
+					Args:  netcatListenArgs(api.ProtocolTCP, numberedPort),
+					Ports: []api.ContainerPort{{Name: portName, ContainerPort: int32(numberedPort), Protocol: api.ProtocolTCP}},
+				},
+				{
+					Name:  fmt.Sprintf("%s-container-%d", podName, extraPort),
+					Image: "gcr.io/google_containers/redis:e2e",
+					Args:  netcatListenArgs(api.ProtocolTCP, extraPort),
+					Ports: []api.ContainerPort{{Name: fmt.Sprintf("%s-extra", portName), ContainerPort: int32(extraPort), Protocol: api.ProtocolTCP}},
+				},
+			},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	svcName := fmt.Sprintf("svc-%s", podName)
+	By(fmt.Sprintf("Creating a service %s for pod %s in namespace %s", svcName, podName, namespace.Name))
+	svc, err := f.ClientSet.Core().Services(namespace.Name).Create(&api.Service{
+		ObjectMeta: api.ObjectMeta{Name: svcName},
+		Spec: api.ServiceSpec{
+			Selector: map[string]string{"pod-name": podName},
+			Ports: []api.ServicePort{
+				{Name: "named", Port: int32(numberedPort), Protocol: api.ProtocolTCP, TargetPort: intstr.FromInt(numberedPort)},
+				{Name: "extra", Port: int32(extraPort), Protocol: api.ProtocolTCP, TargetPort: intstr.FromInt(extraPort)},
+			},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return pod, svc
+}
+
+// createRangePortServerPod starts a single netcat listener per port in ports[], so a
+// NetworkPolicyPort EndPort range can be probed without exploding the number of
+// containers the way one-container-per-port would.
+func createRangePortServerPod(f *framework.Framework, namespace *api.Namespace, podName string, ports ...int) *api.Pod {
+	script := ""
+	for _, port := range ports {
+		script += fmt.Sprintf("/bin/nc -kl %d & ", port)
+	}
+	script += "wait"
+
+	pod, err := f.ClientSet.Core().Pods(namespace.Name).Create(&api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name: podName,
+			Labels: map[string]string{
+				"pod-name": podName,
+			},
+		},
+		Spec: api.PodSpec{
+			RestartPolicy: api.RestartPolicyNever,
+			Containers: []api.Container{
+				{
+					Name:  fmt.Sprintf("%s-container", podName),
+					Image: "gcr.io/google_containers/redis:e2e",
+					Args:  []string{"/bin/sh", "-c", script},
+				},
+			},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return pod
+}
+
 // Create a server pod with a listening container for each port in ports[].
 // Will also assign a pod label with key: "pod-name" and label set to the given podname for later use by the network
 // policy.
 func createServerPodAndService(f *framework.Framework, namespace *api.Namespace, podName string, ports []int) (*api.Pod, *api.Service) {
+	protocols := make([]api.Protocol, len(ports))
+	for i := range protocols {
+		protocols[i] = api.ProtocolTCP
+	}
+	return createServerPodAndServiceProtocol(f, namespace, podName, ports, protocols)
+}
+
+// netcatListenArgs returns the shell command that starts a listener for protocol on
+// port. UDP uses nc -u -kl and SCTP uses nc --sctp -kl, so an SCTP listener only
+// starts if the image's nc supports the --sctp flag.
+func netcatListenArgs(protocol api.Protocol, port int) []string {
+	var cmd string
+	switch protocol {
+	case api.ProtocolUDP:
+		cmd = fmt.Sprintf("/bin/nc -u -kl %d", port)
+	case api.ProtocolSCTP:
+		cmd = fmt.Sprintf("/bin/nc --sctp -kl %d", port)
+	default:
+		cmd = fmt.Sprintf("/bin/nc -kl %d", port)
+	}
+	return []string{"/bin/sh", "-c", cmd}
+}
+
+// netcatConnectArgs returns the shell command a one-shot client pod uses to probe
+// targetIP:targetPort over protocol.
+func netcatConnectArgs(protocol api.Protocol, targetIP string, targetPort int) []string {
+	var cmd string
+	switch protocol {
+	case api.ProtocolUDP:
+		cmd = fmt.Sprintf("/usr/bin/printf dummy-data | /bin/nc -u -w 8 %s %d", targetIP, targetPort)
+	case api.ProtocolSCTP:
+		cmd = fmt.Sprintf("/usr/bin/printf dummy-data | /bin/nc --sctp -w 8 %s %d", targetIP, targetPort)
+	default:
+		cmd = fmt.Sprintf("/usr/bin/printf dummy-data | /bin/nc -w 8 %s %d", targetIP, targetPort)
+	}
+	return []string{"/bin/sh", "-c", cmd}
+}
+
+// createServerPodAndServiceProtocol is createServerPodAndService generalized to a
+// per-port protocol, so NetworkPolicyPort.Protocol can actually be exercised -- until
+// now every test here silently only validated TCP.
+func createServerPodAndServiceProtocol(f *framework.Framework, namespace *api.Namespace, podName string, ports []int, protocols []api.Protocol) (*api.Pod, *api.Service) {
 	// Because we have a variable amount of ports, we'll first loop through and generate our Containers for our pod,
 	// and ServicePorts.for our Service.
 	containers := []api.Container{}
 	servicePorts := []api.ServicePort{}
-	for _, port := range ports {
+	for i, port := range ports {
+		protocol := protocols[i]
 		// Build the containers for the server pod.
 		containers = append(containers, api.Container{
-			Name:  fmt.Sprintf("%s-container-%d", podName, port),
+			Name:  fmt.Sprintf("%s-container-%d-%s", podName, port, strings.ToLower(string(protocol))),
 			Image: "gcr.io/google_containers/redis:e2e",
-			Args: []string{
-				"/bin/sh",
-				"-c",
-				fmt.Sprintf("/bin/nc -kl %d", port),
-			},
-			Ports: []api.ContainerPort{{ContainerPort: int32(port)}},
+			Args:  netcatListenArgs(protocol, port),
+			Ports: []api.ContainerPort{{Name: fmt.Sprintf("serve-%d-%s", port, strings.ToLower(string(protocol))), ContainerPort: int32(port), Protocol: protocol}},
 		})
 
 		// Build the Service Ports for the service.
 		servicePorts = append(servicePorts, api.ServicePort{
-			Name:       fmt.Sprintf("%s-%d", podName, port),
+			Name:       fmt.Sprintf("%s-%d-%s", podName, port, strings.ToLower(string(protocol))),
 			Port:       int32(port),
+			Protocol:   protocol,
 			TargetPort: intstr.FromInt(port),
 		})
 	}
@@ -599,7 +1222,19 @@ func createServerPodAndService(f *framework.Framework, namespace *api.Namespace,
 // Create a client pod which will attempt a netcat to the provided service, on the specified port.
 // This client will attempt a oneshot connection, then die, without restarting the pod.
 // Test can then be asserted based on whether the pod quit with an error or not.
-func createNetworkClientPod(f *framework.Framework, namespace *api.Namespace, podName string, targetIP string, targetPort int) *api.Pod {
+// createNetworkClientPod creates a one-shot client pod that probes targetIP:targetPort.
+// When hostNetwork is true, the pod runs on the node's network namespace so it probes
+// from the node's IP, which is needed to exercise an ipBlock peer against a known
+// source address. nodeName pins the pod to a specific node; leave it empty to let the
+// scheduler pick.
+func createNetworkClientPod(f *framework.Framework, namespace *api.Namespace, podName string, targetIP string, targetPort int, hostNetwork bool, nodeName string) *api.Pod {
+	return createNetworkClientPodProtocol(f, namespace, podName, targetIP, targetPort, api.ProtocolTCP, hostNetwork, nodeName)
+}
+
+// createNetworkClientPodProtocol is createNetworkClientPod generalized to a protocol,
+// so a probe can validate a UDP or SCTP NetworkPolicyPort instead of silently only
+// ever speaking TCP.
+func createNetworkClientPodProtocol(f *framework.Framework, namespace *api.Namespace, podName string, targetIP string, targetPort int, protocol api.Protocol, hostNetwork bool, nodeName string) *api.Pod {
 	pod, err := f.ClientSet.Core().Pods(namespace.Name).Create(&api.Pod{
 		ObjectMeta: api.ObjectMeta{
 			Name: podName,
@@ -609,15 +1244,13 @@ func createNetworkClientPod(f *framework.Framework, namespace *api.Namespace, po
 		},
 		Spec: api.PodSpec{
 			RestartPolicy: api.RestartPolicyNever,
+			HostNetwork:   hostNetwork,
+			NodeName:      nodeName,
 			Containers: []api.Container{
 				{
 					Name:  fmt.Sprintf("%s-container", podName),
 					Image: "gcr.io/google_containers/redis:e2e",
-					Args: []string{
-						"/bin/sh",
-						"-c",
-						fmt.Sprintf("/usr/bin/printf dummy-data | /bin/nc -w 8 %s %d", targetIP, targetPort),
-					},
+					Args:  netcatConnectArgs(protocol, targetIP, targetPort),
 				},
 			},
 		},
@@ -627,26 +1260,41 @@ func createNetworkClientPod(f *framework.Framework, namespace *api.Namespace, po
 	return pod
 }
 
-// Configure namespace network isolation by setting the network-policy annotation
-// on the namespace.
-func setNamespaceIsolation(f *framework.Framework, namespace *api.Namespace, ingressIsolation string) {
-	var annotations = map[string]string{}
-	if ingressIsolation != "" {
+// Configure namespace network isolation by setting the network-policy annotation on
+// the namespace. ingressIsolation and egressIsolation are independent; either may be
+// left empty to leave that direction unisolated.
+//
+// This used to clear ObjectMeta.ResourceVersion and call Update(), which races the
+// namespace controller and any other actor writing annotations concurrently and
+// silently discards their changes. An intermediate version of this function tried to
+// move to Server-Side Apply, but this tree is pinned to a client-go vintage that
+// predates typed SSA clients and applyconfigurations packages, so that never compiled
+// here. It now delegates to EnsureNamespace with MergeOverwrite, which patches only
+// this one annotation key and is the same primitive every other namespace mutation in
+// this package uses.
+func setNamespaceIsolation(f *framework.Framework, namespace *api.Namespace, ingressIsolation, egressIsolation string) {
+	const networkPolicyAnnotation = "net.beta.kubernetes.io/network-policy"
+	opts := EnsureOpts{MergeStrategy: NamespaceMergeOverwrite}
+
+	if ingressIsolation != "" || egressIsolation != "" {
 		By(fmt.Sprintf("Enabling isolation through namespace annotations on namespace %v", namespace.Name))
-		policy := fmt.Sprintf(`{"ingress":{"isolation":"%s"}}`, ingressIsolation)
-		annotations["net.beta.kubernetes.io/network-policy"] = policy
+		directions := []string{}
+		if ingressIsolation != "" {
+			directions = append(directions, fmt.Sprintf(`"ingress":{"isolation":"%s"}`, ingressIsolation))
+		}
+		if egressIsolation != "" {
+			directions = append(directions, fmt.Sprintf(`"egress":{"isolation":"%s"}`, egressIsolation))
+		}
+		opts.Annotations = map[string]string{networkPolicyAnnotation: fmt.Sprintf(`{%s}`, strings.Join(directions, ","))}
 	} else {
 		By(fmt.Sprintf("Disabling isolation through namespace annotations on namespace %v", namespace.Name))
-		delete(annotations, "net.beta.kubernetes.io/network-policy")
+		opts.DeleteAnnotations = []string{networkPolicyAnnotation}
 	}
 
-	// Update the namespace.  We set the resource version to be an empty
-	// string, this forces the update.  If we weren't to do this, we would
-	// either need to re-query the namespace, or update the namespace
-	// references with the one returned by the update.  This approach
-	// requires less plumbing.
-	namespace.ObjectMeta.Annotations = annotations
-	namespace.ObjectMeta.ResourceVersion = ""
-	_, err := f.ClientSet.Core().Namespaces().Update(namespace)
+	_, err := EnsureNamespace(f.ClientSet, namespace.Name, opts)
 	Expect(err).NotTo(HaveOccurred())
 }
+
+func protocolPtr(p api.Protocol) *api.Protocol {
+	return &p
+}
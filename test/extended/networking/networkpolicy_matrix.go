@@ -0,0 +1,406 @@
+package networking
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+	"k8s.io/kubernetes/pkg/util/intstr"
+	"k8s.io/kubernetes/test/e2e/framework"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// This file implements a Cyclonus-style conformance matrix for NetworkPolicy: rather
+// than one Ginkgo It() per scenario, it deploys a fixed mesh of labeled probe pods
+// across a handful of namespaces and, for each policy mutation produced by a small
+// grammar of fragments, computes the expected connectivity truth-table and probes
+// every ordered (src,dst) pair in parallel, diffing observed vs expected into a
+// single report. This gives much deeper coverage than a handful of hand-written
+// Describe/It blocks while still finishing in minutes, because probes are batched.
+
+// matrixNamespaces/matrixPods define the probe mesh: each of matrixNamespaces gets
+// one pod per name in matrixPods, labeled so policies can select any subset of them.
+var matrixNamespaces = []string{"x", "y", "z"}
+var matrixPods = []string{"a", "b", "c"}
+
+const matrixPort = 80
+
+// matrixPod identifies one probe pod in the mesh.
+type matrixPod struct {
+	namespace string // the matrixNamespaces entry, not the generated e2e namespace name
+	name      string
+}
+
+func (p matrixPod) String() string {
+	return fmt.Sprintf("%s/%s", p.namespace, p.name)
+}
+
+// matrixFragment builds one NetworkPolicy mutation (applied to ns/pod it targets) and
+// the predicate(s) that decide whether traffic should be allowed once it, and only
+// it, is in effect. A fragment sets whichever of ingressAllowed/egressAllowed its
+// PolicyTypes actually constrain, and leaves the other nil; the runner only probes a
+// direction when its predicate is non-nil, so a fragment that doesn't touch egress
+// (say) doesn't get scored against egress expectations it never claimed to set.
+type matrixFragment struct {
+	name           string
+	build          func(targetPodName string) *extensions.NetworkPolicy
+	ingressAllowed func(src, dst matrixPod) bool
+	egressAllowed  func(src, dst matrixPod) bool
+}
+
+// targetPodSelector is the PodSelector every fragment uses to scope itself to exactly
+// the mesh pod identified by targetPodName -- the "matrix-pod" label (set to the short
+// matrixPods name, e.g. "a") rather than "pod-name" (set to the generated per-namespace
+// pod name, e.g. "matrix-x-a"), since targetPodName is always the short form.
+func targetPodSelector(targetPodName string) unversioned.LabelSelector {
+	return unversioned.LabelSelector{MatchLabels: map[string]string{"matrix-pod": targetPodName}}
+}
+
+// matrixFragments is the grammar of policy shapes the runner walks. Each fragment is
+// applied on its own to each of the 9 mesh pods in turn, giving 63 scenarios and 648
+// probed (src,dst) tuples, while each individual fragment stays simple and easy to
+// reason about. It covers all 7 kinds
+// called for: default-deny-ingress, default-deny-egress, allow-from-podSelector,
+// allow-from-namespaceSelector, allow-from-ipBlock, allow-to-port, and a PolicyTypes
+// combination (ingress-and-egress-combo) that exercises both directions on the one
+// policy object.
+var matrixFragments = []matrixFragment{
+	{
+		name: "default-deny-ingress",
+		build: func(targetPodName string) *extensions.NetworkPolicy {
+			return &extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{Name: "default-deny-ingress"},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: targetPodSelector(targetPodName),
+				},
+			}
+		},
+		ingressAllowed: func(src, dst matrixPod) bool { return false },
+	},
+	{
+		name: "default-deny-egress",
+		build: func(targetPodName string) *extensions.NetworkPolicy {
+			return &extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{Name: "default-deny-egress"},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: targetPodSelector(targetPodName),
+					PolicyTypes: []extensions.PolicyType{extensions.PolicyTypeEgress},
+				},
+			}
+		},
+		egressAllowed: func(src, dst matrixPod) bool { return false },
+	},
+	{
+		name: "allow-from-podSelector",
+		build: func(targetPodName string) *extensions.NetworkPolicy {
+			return &extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{Name: "allow-from-pod-a"},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: targetPodSelector(targetPodName),
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						From: []extensions.NetworkPolicyPeer{{
+							PodSelector: &unversioned.LabelSelector{MatchLabels: map[string]string{"matrix-pod": "a"}},
+						}},
+					}},
+				},
+			}
+		},
+		// A PodSelector peer with no NamespaceSelector only matches pods in the
+		// policy's own namespace, not cluster-wide.
+		ingressAllowed: func(src, dst matrixPod) bool { return src.name == "a" && src.namespace == dst.namespace },
+	},
+	{
+		name: "allow-from-namespaceSelector",
+		build: func(targetPodName string) *extensions.NetworkPolicy {
+			return &extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{Name: "allow-from-ns-x"},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: targetPodSelector(targetPodName),
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						From: []extensions.NetworkPolicyPeer{{
+							NamespaceSelector: &unversioned.LabelSelector{MatchLabels: map[string]string{"matrix-ns": "x"}},
+						}},
+					}},
+				},
+			}
+		},
+		ingressAllowed: func(src, dst matrixPod) bool { return src.namespace == "x" },
+	},
+	{
+		name: "allow-from-ipBlock",
+		build: func(targetPodName string) *extensions.NetworkPolicy {
+			return &extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{Name: "allow-from-ipblock-any"},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: targetPodSelector(targetPodName),
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						From: []extensions.NetworkPolicyPeer{{
+							IPBlock: &extensions.IPBlock{CIDR: "0.0.0.0/0"},
+						}},
+					}},
+				},
+			}
+		},
+		// A catch-all CIDR: this exercises the ipBlock peer type at all (parsing,
+		// plumbing an IPBlock-only rule through to the backend); the dedicated
+		// ipBlock/except test covers CIDR partitioning in depth.
+		ingressAllowed: func(src, dst matrixPod) bool { return true },
+	},
+	{
+		name: "allow-to-port",
+		build: func(targetPodName string) *extensions.NetworkPolicy {
+			return &extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{Name: "allow-ingress-on-matrix-port"},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: targetPodSelector(targetPodName),
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						Ports: []extensions.NetworkPolicyPort{{Port: &intstr.IntOrString{IntVal: matrixPort}}},
+					}},
+				},
+			}
+		},
+		ingressAllowed: func(src, dst matrixPod) bool { return true },
+	},
+	{
+		name: "ingress-and-egress-combo",
+		build: func(targetPodName string) *extensions.NetworkPolicy {
+			return &extensions.NetworkPolicy{
+				ObjectMeta: api.ObjectMeta{Name: "allow-ingress-a-deny-egress"},
+				Spec: extensions.NetworkPolicySpec{
+					PodSelector: targetPodSelector(targetPodName),
+					PolicyTypes: []extensions.PolicyType{extensions.PolicyTypeIngress, extensions.PolicyTypeEgress},
+					Ingress: []extensions.NetworkPolicyIngressRule{{
+						From: []extensions.NetworkPolicyPeer{{
+							PodSelector: &unversioned.LabelSelector{MatchLabels: map[string]string{"matrix-pod": "a"}},
+						}},
+					}},
+					// No Egress rules: combined with PolicyTypes including Egress,
+					// this denies all egress while the Ingress rule above still
+					// allows matrix-pod "a" in -- the PolicyTypes combination the
+					// grammar was missing.
+				},
+			}
+		},
+		ingressAllowed: func(src, dst matrixPod) bool { return src.name == "a" && src.namespace == dst.namespace },
+		egressAllowed:  func(src, dst matrixPod) bool { return false },
+	},
+}
+
+// matrixResult is one probed (src,dst) outcome.
+type matrixResult struct {
+	src, dst matrixPod
+	expected bool
+	observed bool
+}
+
+var _ = Describe("NetworkPolicy conformance matrix", func() {
+	InNetworkPolicyContext(func() {
+		f := framework.NewDefaultFramework("network-policy-matrix")
+
+		It("should match the expected connectivity truth-table for every policy fragment [Feature:NetworkPolicy][Slow]", func() {
+			nsByLabel, podByKey, services := deployMatrixMesh(f)
+			defer cleanupMatrixMesh(f, nsByLabel, podByKey)
+
+			failures := []string{}
+			total := 0
+			for _, targetNS := range matrixNamespaces {
+				for _, targetPodName := range matrixPods {
+					for _, frag := range matrixFragments {
+						policy := frag.build(targetPodName)
+						ns := nsByLabel[targetNS]
+						By(fmt.Sprintf("Applying fragment %q to %s/%s", frag.name, targetNS, targetPodName))
+						_, err := f.ClientSet.Extensions().NetworkPolicies(ns.Name).Create(policy)
+						Expect(err).NotTo(HaveOccurred())
+
+						var results []matrixResult
+						if frag.ingressAllowed != nil {
+							results = append(results, probeMatrix(f, nsByLabel, services, targetNS, targetPodName, frag.ingressAllowed)...)
+						}
+						if frag.egressAllowed != nil {
+							results = append(results, probeMatrixEgress(f, nsByLabel, services, targetNS, targetPodName, frag.egressAllowed)...)
+						}
+						total += len(results)
+						for _, r := range results {
+							if r.observed != r.expected {
+								failures = append(failures, fmt.Sprintf("[%s on %s] %s -> %s: expected=%v observed=%v",
+									frag.name, matrixPod{targetNS, targetPodName}, r.src, r.dst, r.expected, r.observed))
+							}
+						}
+
+						err = f.ClientSet.Extensions().NetworkPolicies(ns.Name).Delete(policy.Name, nil)
+						Expect(err).NotTo(HaveOccurred())
+					}
+				}
+			}
+
+			framework.Logf("Matrix runner probed %d (src,dst) tuples across %d target pods x %d fragments", total, len(matrixNamespaces)*len(matrixPods), len(matrixFragments))
+			Expect(failures).To(BeEmpty(), fmt.Sprintf("truth-table mismatches:\n%s", joinLines(failures)))
+		})
+	})
+})
+
+// deployMatrixMesh creates one namespace per matrixNamespaces entry (each labeled
+// "matrix-ns") and one server pod+service per matrixPods entry in each (each labeled
+// "matrix-pod" and "pod-name"), returning lookup maps keyed by the logical names used
+// in matrixFragments/matrixPod.
+func deployMatrixMesh(f *framework.Framework) (map[string]*api.Namespace, map[matrixPod]*api.Pod, map[matrixPod]*api.Service) {
+	nsByLabel := map[string]*api.Namespace{}
+	podByKey := map[matrixPod]*api.Pod{}
+	svcByKey := map[matrixPod]*api.Service{}
+
+	for i, nsLabel := range matrixNamespaces {
+		var ns *api.Namespace
+		var err error
+		if i == 0 {
+			ns = f.Namespace
+		} else {
+			ns, err = f.CreateNamespace(fmt.Sprintf("netpolmatrix-%s", nsLabel), map[string]string{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		updated, err := EnsureNamespace(f.ClientSet, ns.Name, EnsureOpts{
+			Labels:        map[string]string{"matrix-ns": nsLabel},
+			MergeStrategy: NamespaceMergeOverwrite,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		nsByLabel[nsLabel] = updated
+
+		for _, podLabel := range matrixPods {
+			podName := fmt.Sprintf("matrix-%s-%s", nsLabel, podLabel)
+			pod, svc := createServerPodAndService(f, updated, podName, []int{matrixPort})
+			pod.ObjectMeta.Labels["matrix-pod"] = podLabel
+			_, err := f.ClientSet.Core().Pods(updated.Name).Update(pod)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(framework.WaitForPodRunningInNamespace(f.ClientSet, pod)).NotTo(HaveOccurred())
+
+			key := matrixPod{namespace: nsLabel, name: podLabel}
+			podByKey[key] = pod
+			svcByKey[key] = svc
+		}
+	}
+	return nsByLabel, podByKey, svcByKey
+}
+
+func cleanupMatrixMesh(f *framework.Framework, nsByLabel map[string]*api.Namespace, podByKey map[matrixPod]*api.Pod) {
+	for key, pod := range podByKey {
+		ns := nsByLabel[key.namespace]
+		if err := f.ClientSet.Core().Pods(ns.Name).Delete(pod.Name, nil); err != nil {
+			framework.Logf("unable to cleanup matrix pod %s: %v", pod.Name, err)
+		}
+	}
+}
+
+// probeMatrix batches every ordered (src,dst) pair where dst is the policy's current
+// target pod, running the client probes concurrently so a full fragment's worth of
+// the mesh finishes in one round-trip instead of len(mesh) sequential probes.
+func probeMatrix(f *framework.Framework, nsByLabel map[string]*api.Namespace, services map[matrixPod]*api.Service, targetNS, targetPodName string, allowed func(src, dst matrixPod) bool) []matrixResult {
+	dst := matrixPod{namespace: targetNS, name: targetPodName}
+	service := services[dst]
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := []matrixResult{}
+
+	for _, srcNS := range matrixNamespaces {
+		for _, srcPodName := range matrixPods {
+			src := matrixPod{namespace: srcNS, name: srcPodName}
+			wg.Add(1)
+			go func(src matrixPod) {
+				defer wg.Done()
+				clientNS := nsByLabel[src.namespace]
+				clientName := fmt.Sprintf("matrix-probe-%s-%s-to-%s-%s", src.namespace, src.name, dst.namespace, dst.name)
+				observed := probeCanConnectAs(f, clientNS, clientName, src.name, service, matrixPort)
+
+				mu.Lock()
+				results = append(results, matrixResult{src: src, dst: dst, expected: allowed(src, dst), observed: observed})
+				mu.Unlock()
+			}(src)
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// probeCanConnect runs one client probe and reports whether it succeeded, without
+// failing the test itself -- the matrix runner collects mismatches and reports them
+// together at the end.
+func probeCanConnect(f *framework.Framework, ns *api.Namespace, podName string, service *api.Service, targetPort int) bool {
+	podClient := createNetworkClientPod(f, ns, podName, service.Spec.ClusterIP, targetPort, false, "")
+	defer func() {
+		if err := f.ClientSet.Core().Pods(ns.Name).Delete(podClient.Name, nil); err != nil {
+			framework.Logf("unable to cleanup probe pod %v: %v", podClient.Name, err)
+		}
+	}()
+
+	if err := framework.WaitForPodNoLongerRunningInNamespace(f.ClientSet, podClient.Name, ns.Name, "0"); err != nil {
+		return false
+	}
+	return framework.WaitForPodSuccessInNamespace(f.ClientSet, podClient.Name, ns.Name) == nil
+}
+
+// probeMatrixEgress is probeMatrix with the roles reversed: the fragment's current
+// target pod is the connection-initiating source, probed against every other mesh pod
+// as destination, since an egress-direction fragment constrains what the target is
+// allowed to reach rather than who may reach it.
+func probeMatrixEgress(f *framework.Framework, nsByLabel map[string]*api.Namespace, services map[matrixPod]*api.Service, targetNS, targetPodName string, allowed func(src, dst matrixPod) bool) []matrixResult {
+	src := matrixPod{namespace: targetNS, name: targetPodName}
+	srcNS := nsByLabel[src.namespace]
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := []matrixResult{}
+
+	for _, dstNS := range matrixNamespaces {
+		for _, dstPodName := range matrixPods {
+			dst := matrixPod{namespace: dstNS, name: dstPodName}
+			service := services[dst]
+			wg.Add(1)
+			go func(dst matrixPod, service *api.Service) {
+				defer wg.Done()
+				clientName := fmt.Sprintf("matrix-probe-%s-%s-to-%s-%s", src.namespace, src.name, dst.namespace, dst.name)
+				observed := probeCanConnectAs(f, srcNS, clientName, src.name, service, matrixPort)
+
+				mu.Lock()
+				results = append(results, matrixResult{src: src, dst: dst, expected: allowed(src, dst), observed: observed})
+				mu.Unlock()
+			}(dst, service)
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// probeCanConnectAs is probeCanConnect for a probe that stands in for a mesh pod: the
+// client pod must carry the "matrix-pod" label of the mesh pod it is standing in for,
+// since ingress fragments match their source peers by that label and egress fragments
+// select their target (the policy's PodSelector) by it. createNetworkClientPod only
+// ever labels a pod "pod-name", so the "matrix-pod"
+// label is patched on immediately after creation, mirroring how deployMatrixMesh labels
+// the real mesh pods.
+func probeCanConnectAs(f *framework.Framework, ns *api.Namespace, podName string, matrixPodLabel string, service *api.Service, targetPort int) bool {
+	podClient := createNetworkClientPod(f, ns, podName, service.Spec.ClusterIP, targetPort, false, "")
+	podClient.ObjectMeta.Labels["matrix-pod"] = matrixPodLabel
+	updated, err := f.ClientSet.Core().Pods(ns.Name).Update(podClient)
+	Expect(err).NotTo(HaveOccurred())
+	defer func() {
+		if err := f.ClientSet.Core().Pods(ns.Name).Delete(updated.Name, nil); err != nil {
+			framework.Logf("unable to cleanup probe pod %v: %v", updated.Name, err)
+		}
+	}()
+
+	if err := framework.WaitForPodNoLongerRunningInNamespace(f.ClientSet, updated.Name, ns.Name, "0"); err != nil {
+		return false
+	}
+	return framework.WaitForPodSuccessInNamespace(f.ClientSet, updated.Name, ns.Name) == nil
+}
+
+func joinLines(lines []string) string {
+	out := ""
+	for _, l := range lines {
+		out += l + "\n"
+	}
+	return out
+}